@@ -0,0 +1,203 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+func showUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s COMMAND [flags] ...\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  list SITES_FILE [MASTER_PASS_FILE]\n")
+	fmt.Fprintf(os.Stderr, "        Print every site's derived password.\n")
+	fmt.Fprintf(os.Stderr, "  get SITES_FILE SITE_NAME [MASTER_PASS_FILE]\n")
+	fmt.Fprintf(os.Stderr, "        Print one site's derived password.\n")
+	fmt.Fprintf(os.Stderr, "  copy SITES_FILE SITE_NAME [MASTER_PASS_FILE]\n")
+	fmt.Fprintf(os.Stderr, "        Copy one site's derived password to the clipboard.\n")
+	fmt.Fprintf(os.Stderr, "  completion SHELL SITES_FILE\n")
+	fmt.Fprintf(os.Stderr, "        Print a bash, zsh, or fish completion script.\n")
+	fmt.Fprintf(os.Stderr, "  import --format=lesspass|mpsites|csv INPUT_FILE\n")
+	fmt.Fprintf(os.Stderr, "        Translate another password manager's export into a sites file.\n")
+	fmt.Fprintf(os.Stderr, "  export SITES_FILE [MASTER_PASS_FILE]\n")
+	fmt.Fprintf(os.Stderr, "        Print a CSV export (site,username,length,charset).\n\n")
+	fmt.Fprintf(os.Stderr, "If MASTER_PASS_FILE is omitted or is \"-\", the master password is\n")
+	fmt.Fprintf(os.Stderr, "read from the controlling TTY instead.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		showUsage()
+		os.Exit(-1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = cmdList(os.Args[2:])
+	case "get":
+		err = cmdGet(os.Args[2:])
+	case "copy":
+		err = cmdCopy(os.Args[2:])
+	case "completion":
+		err = cmdCompletion(os.Args[2:])
+	case "import":
+		err = cmdImport(os.Args[2:])
+	case "export":
+		err = cmdExport(os.Args[2:])
+	case "-h", "--help", "help":
+		showUsage()
+		return
+	default:
+		showUsage()
+		os.Exit(-1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kagi: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// masterPasswordFlags registers the flags shared by every command that
+// derives site passwords from a master password.
+func masterPasswordFlags(fs *flag.FlagSet) (confirm, confirmHash *bool) {
+	confirm = fs.Bool("confirm", false, "re-prompt for the master password to confirm it")
+	confirmHash = fs.Bool("confirm-hash", false, "print a short hash of the entered master password for visual verification")
+	return
+}
+
+// resolveMasterPassword loads the master password from masterFile, or
+// prompts for it on the controlling TTY if masterFile is empty or "-".
+func resolveMasterPassword(masterFile string, confirm bool) string {
+	if masterFile != "" && masterFile != "-" {
+		return loadMasterPassword(masterFile)
+	}
+	return promptMasterPassword(confirm)
+}
+
+func findSite(sites []*Site, name string) (*Site, error) {
+	for _, site := range sites {
+		if site.Name == name {
+			return site, nil
+		}
+	}
+	return nil, fmt.Errorf("no such site: %s", name)
+}
+
+func cmdList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	confirm, confirmHash := masterPasswordFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 && fs.NArg() != 2 {
+		return fmt.Errorf("usage: %s list [flags] SITES_FILE [MASTER_PASS_FILE]", os.Args[0])
+	}
+	sitesFile, masterFile := fs.Arg(0), fs.Arg(1)
+
+	sites, err := loadSites(sitesFile)
+	if err != nil {
+		return err
+	}
+	checkCounters(sites, counterStateFilename(sitesFile))
+	master := resolveMasterPassword(masterFile, *confirm)
+	if *confirmHash {
+		printConfirmHash(master)
+	}
+
+	longestSiteLen := 0
+	for _, site := range sites {
+		if longestSiteLen < len(site.Name) {
+			longestSiteLen = len(site.Name)
+		}
+	}
+	for _, site := range sites {
+		spaceStr := strings.Repeat(" ", longestSiteLen-len(site.Name)+1)
+		fmt.Printf("%s:%s%s\n", site.Name, spaceStr, site.Password(master))
+	}
+	return nil
+}
+
+func cmdGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	confirm, confirmHash := masterPasswordFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 2 && fs.NArg() != 3 {
+		return fmt.Errorf("usage: %s get [flags] SITES_FILE SITE_NAME [MASTER_PASS_FILE]", os.Args[0])
+	}
+	sitesFile, siteName, masterFile := fs.Arg(0), fs.Arg(1), fs.Arg(2)
+
+	sites, err := loadSites(sitesFile)
+	if err != nil {
+		return err
+	}
+	checkCounters(sites, counterStateFilename(sitesFile))
+	site, err := findSite(sites, siteName)
+	if err != nil {
+		return err
+	}
+	master := resolveMasterPassword(masterFile, *confirm)
+	if *confirmHash {
+		printConfirmHash(master)
+	}
+	fmt.Println(site.Password(master))
+	return nil
+}
+
+func cmdCopy(args []string) error {
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	confirm, confirmHash := masterPasswordFlags(fs)
+	clearAfter := fs.Duration("clear-after", 30*time.Second, "clear the clipboard after this long (0 disables auto-clear)")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 && fs.NArg() != 3 {
+		return fmt.Errorf("usage: %s copy [flags] SITES_FILE SITE_NAME [MASTER_PASS_FILE]", os.Args[0])
+	}
+	sitesFile, siteName, masterFile := fs.Arg(0), fs.Arg(1), fs.Arg(2)
+
+	sites, err := loadSites(sitesFile)
+	if err != nil {
+		return err
+	}
+	checkCounters(sites, counterStateFilename(sitesFile))
+	site, err := findSite(sites, siteName)
+	if err != nil {
+		return err
+	}
+	master := resolveMasterPassword(masterFile, *confirm)
+	if *confirmHash {
+		printConfirmHash(master)
+	}
+
+	password := site.Password(master)
+	if err := clipboard.WriteAll(password); err != nil {
+		return fmt.Errorf("copy to clipboard: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Copied password for %s to the clipboard.\n", site.Name)
+
+	if *clearAfter > 0 {
+		time.Sleep(*clearAfter)
+		if cur, err := clipboard.ReadAll(); err == nil && cur == password {
+			clipboard.WriteAll("")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func cmdCompletion(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: kagi completion SHELL SITES_FILE")
+	}
+	shell, sitesFile := args[0], args[1]
+
+	sites, err := loadSites(sitesFile)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(sites))
+	for i, site := range sites {
+		names[i] = site.Name
+	}
+
+	switch shell {
+	case "bash":
+		fmt.Print(bashCompletion(names))
+	case "zsh":
+		fmt.Print(zshCompletion(names))
+	case "fish":
+		fmt.Print(fishCompletion(names))
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh, or fish)", shell)
+	}
+	return nil
+}
+
+func bashCompletion(siteNames []string) string {
+	return fmt.Sprintf(`_kagi() {
+	local cur cmds
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	cmds="list get copy completion import export"
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "$cmds" -- "$cur"))
+		return
+	fi
+	case "${COMP_WORDS[1]}" in
+	get|copy)
+		if [ "$COMP_CWORD" -eq 3 ]; then
+			COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		fi
+		;;
+	esac
+}
+complete -F _kagi kagi
+`, strings.Join(siteNames, " "))
+}
+
+func zshCompletion(siteNames []string) string {
+	return fmt.Sprintf(`#compdef kagi
+_kagi() {
+	local -a cmds sites
+	cmds=(list get copy completion import export)
+	sites=(%s)
+	if (( CURRENT == 2 )); then
+		_describe 'command' cmds
+	elif (( CURRENT == 4 )) && [[ ${words[2]} == (get|copy) ]]; then
+		_describe 'site' sites
+	fi
+}
+_kagi
+`, strings.Join(siteNames, " "))
+}
+
+func fishCompletion(siteNames []string) string {
+	return fmt.Sprintf(`complete -c kagi -n "__fish_use_subcommand" -a "list get copy completion import export"
+complete -c kagi -n "__fish_seen_subcommand_from get copy" -a "%s"
+`, strings.Join(siteNames, " "))
+}
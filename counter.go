@@ -0,0 +1,124 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCounter parses a `# @counter N` directive line and reports the
+// counter it selects, or ok == false if the line is not a recognized
+// `@counter` directive.
+func ParseCounter(line string) (counter int, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return 0, false
+	}
+	if fields[1] != "@counter" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// counterStateFilename returns the sidecar file kagi uses to remember the
+// counter it last saw for each site in sitesFilename.
+func counterStateFilename(sitesFilename string) string {
+	return sitesFilename + ".counters"
+}
+
+func loadCounterState(filename string) map[string]int {
+	state := map[string]int{}
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state
+		}
+		panic(err)
+	}
+	defer file.Close()
+	fileContent, err := io.ReadAll(file)
+	if err != nil {
+		panic(err)
+	}
+	for _, line := range strings.Split(string(fileContent), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		state[fields[0]] = n
+	}
+	return state
+}
+
+func saveCounterState(filename string, state map[string]int) error {
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s %d\n", name, state[name])
+	}
+	return os.WriteFile(filename, []byte(b.String()), 0600)
+}
+
+// checkCounters compares each site's counter against the value kagi saw the
+// last time it ran, warns about any that changed, and persists the current
+// values for next time. It only touches the sidecar file when something
+// actually changed, so a read-only command against an up-to-date sites file
+// has no filesystem side effect, and a write failure (e.g. a read-only
+// sites directory) is reported rather than crashing the command.
+func checkCounters(sites []*Site, filename string) {
+	prev := loadCounterState(filename)
+	current := make(map[string]int, len(sites))
+	for _, site := range sites {
+		current[site.Name] = site.Counter
+	}
+
+	changed := len(current) != len(prev)
+	for name, counter := range current {
+		old, ok := prev[name]
+		if !ok {
+			changed = true
+			continue
+		}
+		if old != counter {
+			fmt.Fprintf(os.Stderr,
+				"WARN: counter for %s changed from %d to %d.\n",
+				name, old, counter)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := saveCounterState(filename, current); err != nil {
+		fmt.Fprintf(os.Stderr, "WARN: could not save %s: %v\n", filename, err)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// importedSite is the intermediate form every import parser produces. It is
+// translated into a kagi sites file entry by sitesFileEntry.
+type importedSite struct {
+	Name     string
+	Username string
+	Length   int
+	Charset  string // "numeric", "lower", "upper", "alphanumeric", or "alphanumeric+symbols"
+	Counter  int
+}
+
+func cmdImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "input format: lesspass, mpsites, or csv")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s import --format=lesspass|mpsites|csv INPUT_FILE", os.Args[0])
+	}
+	inputFile := fs.Arg(0)
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return err
+	}
+
+	var sites []importedSite
+	switch *format {
+	case "lesspass":
+		sites, err = parseLessPass(data)
+	case "mpsites":
+		sites, err = parseMPSites(data)
+	case "csv":
+		sites, err = parseGenericCSV(data)
+	default:
+		return fmt.Errorf("unsupported --format %q (want lesspass, mpsites, or csv)", *format)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", inputFile, err)
+	}
+
+	for _, site := range sites {
+		fmt.Print(sitesFileEntry(site))
+	}
+	return nil
+}
+
+func cmdExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	confirm, confirmHash := masterPasswordFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 && fs.NArg() != 2 {
+		return fmt.Errorf("usage: %s export [flags] SITES_FILE [MASTER_PASS_FILE]", os.Args[0])
+	}
+	sitesFile, masterFile := fs.Arg(0), fs.Arg(1)
+
+	sites, err := loadSites(sitesFile)
+	if err != nil {
+		return err
+	}
+	checkCounters(sites, counterStateFilename(sitesFile))
+	master := resolveMasterPassword(masterFile, *confirm)
+	if *confirmHash {
+		printConfirmHash(master)
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{"site", "username", "length", "charset"}); err != nil {
+		return err
+	}
+	for _, site := range sites {
+		password := site.Password(master)
+		row := []string{
+			site.Name,
+			"",
+			strconv.Itoa(len(password)),
+			classifyCharset(password),
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// sitesFileEntry renders an importedSite as a self-contained group in kagi's
+// sites file format: the directives needed to reproduce its length and
+// character class, followed by the site name, followed by a blank line so
+// the directives don't leak into whatever comes next.
+func sitesFileEntry(site importedSite) string {
+	var b strings.Builder
+	if site.Counter != 0 {
+		fmt.Fprintf(&b, "# @counter %d\n", site.Counter)
+	}
+	for _, filter := range charsetFilters(site.Length, site.Charset) {
+		fmt.Fprintf(&b, "# %s\n", filter)
+	}
+	fmt.Fprintf(&b, "%s\n\n", site.Name)
+	return b.String()
+}
+
+// charsetFilters returns the `@...` filter directives that narrow kagi's
+// base64 output down to the given length and character class, in the order
+// they must be applied: strip unwanted characters first, then truncate to
+// length last so the final password is exactly as long as requested.
+func charsetFilters(length int, charset string) []string {
+	var filters []string
+	switch charset {
+	case "numeric":
+		filters = append(filters, "@digit")
+	case "lower":
+		filters = append(filters, "@skip 0123456789+/", "@lowercase")
+	case "upper":
+		filters = append(filters, "@skip 0123456789+/", "@uppercase")
+	case "alphanumeric":
+		filters = append(filters, "@skip +/")
+	}
+	if length > 0 {
+		filters = append(filters, fmt.Sprintf("@substring 0 %d", length))
+	}
+	return filters
+}
+
+// classifyCharset reports the narrowest character class that covers every
+// character of password, for use by export. It is the reverse of
+// charsetFilters: since kagi doesn't retain the original @-directives as
+// data, export infers the class from the password actually produced.
+func classifyCharset(password string) string {
+	hasLower, hasUpper, hasDigit, hasOther := false, false, false, false
+	for _, r := range password {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		default:
+			hasOther = true
+		}
+	}
+	switch {
+	case hasOther:
+		return "alphanumeric+symbols"
+	case hasDigit && !hasLower && !hasUpper:
+		return "numeric"
+	case hasLower && !hasUpper:
+		return "lower"
+	case hasUpper && !hasLower:
+		return "upper"
+	default:
+		return "alphanumeric"
+	}
+}
+
+// parseGenericCSV reads the "site,username,length,charset" CSV produced by
+// export (and by Bitwarden/1Password-style tooling).
+func parseGenericCSV(data []byte) ([]importedSite, error) {
+	r := csv.NewReader(strings.NewReader(string(data)))
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	sites := make([]importedSite, 0, len(records))
+	for i, record := range records {
+		if i == 0 && isCSVHeader(record) {
+			continue
+		}
+		if len(record) < 4 {
+			return nil, fmt.Errorf("line %d: want 4 fields (site,username,length,charset), got %d", i+1, len(record))
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(record[2]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid length %q: %w", i+1, record[2], err)
+		}
+		sites = append(sites, importedSite{
+			Name:     strings.TrimSpace(record[0]),
+			Username: strings.TrimSpace(record[1]),
+			Length:   length,
+			Charset:  strings.TrimSpace(record[3]),
+		})
+	}
+	return sites, nil
+}
+
+func isCSVHeader(record []string) bool {
+	return len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "site")
+}
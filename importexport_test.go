@@ -0,0 +1,135 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseLessPass(t *testing.T) {
+	data, err := os.ReadFile("testdata/lesspass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sites, err := parseLessPass(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 2 {
+		t.Fatalf("got %d sites, want 2", len(sites))
+	}
+	if got, want := sites[0], (importedSite{
+		Name: "example.com", Username: "alice@example.com",
+		Length: 16, Charset: "alphanumeric", Counter: 1,
+	}); got != want {
+		t.Errorf("sites[0] = %+v, want %+v", got, want)
+	}
+	if got, want := sites[1], (importedSite{
+		Name: "bank.example.com", Username: "alice",
+		Length: 6, Charset: "numeric", Counter: 2,
+	}); got != want {
+		t.Errorf("sites[1] = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMPSites(t *testing.T) {
+	data, err := os.ReadFile("testdata/export.mpsites")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sites, err := parseMPSites(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 2 {
+		t.Fatalf("got %d sites, want 2", len(sites))
+	}
+	if got, want := sites[0], (importedSite{
+		Name: "example.com", Username: "alice",
+		Length: 14, Charset: "alphanumeric", Counter: 1,
+	}); got != want {
+		t.Errorf("sites[0] = %+v, want %+v", got, want)
+	}
+	if got, want := sites[1], (importedSite{
+		Name: "bank.example.com", Username: "alice",
+		Length: 4, Charset: "numeric", Counter: 1,
+	}); got != want {
+		t.Errorf("sites[1] = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseGenericCSV(t *testing.T) {
+	data, err := os.ReadFile("testdata/export.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sites, err := parseGenericCSV(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 2 {
+		t.Fatalf("got %d sites, want 2", len(sites))
+	}
+	if got, want := sites[0], (importedSite{
+		Name: "example.com", Username: "alice",
+		Length: 16, Charset: "alphanumeric",
+	}); got != want {
+		t.Errorf("sites[0] = %+v, want %+v", got, want)
+	}
+}
+
+func TestCharsetFilters(t *testing.T) {
+	tests := []struct {
+		length  int
+		charset string
+		want    []string
+	}{
+		{16, "numeric", []string{"@digit", "@substring 0 16"}},
+		{8, "alphanumeric", []string{"@skip +/", "@substring 0 8"}},
+		{0, "alphanumeric", []string{"@skip +/"}},
+		{8, "lower", []string{"@skip 0123456789+/", "@lowercase", "@substring 0 8"}},
+	}
+	for _, tt := range tests {
+		got := charsetFilters(tt.length, tt.charset)
+		if len(got) != len(tt.want) {
+			t.Errorf("charsetFilters(%d, %q) = %v, want %v", tt.length, tt.charset, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("charsetFilters(%d, %q)[%d] = %q, want %q", tt.length, tt.charset, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestClassifyCharset(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"12345678", "numeric"},
+		{"abcdefgh", "lower"},
+		{"ABCDEFGH", "upper"},
+		{"abcABC123", "alphanumeric"},
+		{"abcABC123+/", "alphanumeric+symbols"},
+	}
+	for _, tt := range tests {
+		if got := classifyCharset(tt.password); got != tt.want {
+			t.Errorf("classifyCharset(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF derives the raw key material a site's password is built from. Name is
+// the site name and master is the user's master password.
+type KDF interface {
+	Derive(name, master string) []byte
+}
+
+// defaultKDF is used for sites without a `# @kdf` directive. Argon2id is the
+// default so a sites file gets memory-hard derivation without needing any
+// directive; add `# @kdf sha512` to a site (or group) to keep deriving the
+// same passwords an older version of this tool produced.
+var defaultKDF KDF = &argon2idKDF{
+	time:        1,
+	memory:      64 * 1024,
+	parallelism: 4,
+	keyLen:      32,
+}
+
+type sha512KDF struct{}
+
+func (k *sha512KDF) Derive(name, master string) []byte {
+	sum := sha512.Sum512([]byte(name + ":" + master))
+	return sum[:]
+}
+
+// The salt is deterministic so that passwords stay reproducible without
+// persisting any state: it is derived from the site name, optionally
+// prefixed with a user-supplied pepper.
+func salt(saltPrefix, name string) []byte {
+	return []byte(saltPrefix + name)
+}
+
+type argon2idKDF struct {
+	time        uint32
+	memory      uint32
+	parallelism uint8
+	keyLen      uint32
+	saltPrefix  string
+}
+
+func (k *argon2idKDF) Derive(name, master string) []byte {
+	return argon2.IDKey([]byte(master), salt(k.saltPrefix, name), k.time, k.memory, k.parallelism, k.keyLen)
+}
+
+type scryptKDF struct {
+	n          int
+	r          int
+	p          int
+	keyLen     int
+	saltPrefix string
+}
+
+func (k *scryptKDF) Derive(name, master string) []byte {
+	key, err := scrypt.Key([]byte(master), salt(k.saltPrefix, name), k.n, k.r, k.p, k.keyLen)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// kdfArgs parses a list of "key=value" fields as produced by strings.Fields.
+func kdfArgs(fields []string) map[string]string {
+	args := map[string]string{}
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		args[kv[0]] = kv[1]
+	}
+	return args
+}
+
+func kdfArgUint32(args map[string]string, key string, def uint32) (uint32, error) {
+	v, ok := args[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", key, v)
+	}
+	return uint32(n), nil
+}
+
+func kdfArgInt(args map[string]string, key string, def int) (int, error) {
+	v, ok := args[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", key, v)
+	}
+	return n, nil
+}
+
+// ParseKDF parses a `# @kdf ...` directive line and returns the KDF it
+// selects. It returns nil, nil if the line is not an `@kdf` directive, and a
+// non-nil error if it is one but names an unknown algorithm or gives it
+// parameters that would make the underlying library panic at derivation
+// time (e.g. a zero parallelism or a non-power-of-two scrypt N).
+func ParseKDF(line string) (KDF, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[1] != "@kdf" {
+		return nil, nil
+	}
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("@kdf: missing algorithm name")
+	}
+	args := kdfArgs(fields[3:])
+	switch fields[2] {
+	case "sha512":
+		return &sha512KDF{}, nil
+	case "argon2id":
+		time, err := kdfArgUint32(args, "time", 1)
+		if err != nil {
+			return nil, fmt.Errorf("@kdf argon2id: %w", err)
+		}
+		memory, err := kdfArgUint32(args, "memory", 64*1024)
+		if err != nil {
+			return nil, fmt.Errorf("@kdf argon2id: %w", err)
+		}
+		parallelism, err := kdfArgUint32(args, "parallelism", 4)
+		if err != nil {
+			return nil, fmt.Errorf("@kdf argon2id: %w", err)
+		}
+		if time < 1 {
+			return nil, fmt.Errorf("@kdf argon2id: time must be at least 1")
+		}
+		if parallelism < 1 || parallelism > 255 {
+			return nil, fmt.Errorf("@kdf argon2id: parallelism must be between 1 and 255")
+		}
+		if memory < 8*parallelism {
+			return nil, fmt.Errorf("@kdf argon2id: memory (KiB) must be at least 8x parallelism")
+		}
+		return &argon2idKDF{
+			time:        time,
+			memory:      memory,
+			parallelism: uint8(parallelism),
+			keyLen:      32,
+			saltPrefix:  args["saltprefix"],
+		}, nil
+	case "scrypt":
+		n, err := kdfArgInt(args, "N", 32768)
+		if err != nil {
+			return nil, fmt.Errorf("@kdf scrypt: %w", err)
+		}
+		r, err := kdfArgInt(args, "r", 8)
+		if err != nil {
+			return nil, fmt.Errorf("@kdf scrypt: %w", err)
+		}
+		p, err := kdfArgInt(args, "p", 1)
+		if err != nil {
+			return nil, fmt.Errorf("@kdf scrypt: %w", err)
+		}
+		if n <= 1 || n&(n-1) != 0 {
+			return nil, fmt.Errorf("@kdf scrypt: N must be a power of two greater than 1")
+		}
+		if r < 1 || p < 1 {
+			return nil, fmt.Errorf("@kdf scrypt: r and p must be at least 1")
+		}
+		return &scryptKDF{
+			n:          n,
+			r:          r,
+			p:          p,
+			keyLen:     32,
+			saltPrefix: args["saltprefix"],
+		}, nil
+	default:
+		return nil, fmt.Errorf("@kdf: unknown algorithm %q", fields[2])
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "encoding/json"
+
+// lessPassEntry mirrors the fields of a LessPass JSON export record.
+type lessPassEntry struct {
+	Site      string `json:"site"`
+	Login     string `json:"login"`
+	Lowercase bool   `json:"lowercase"`
+	Uppercase bool   `json:"uppercase"`
+	Numbers   bool   `json:"numbers"`
+	Symbols   bool   `json:"symbols"`
+	Length    int    `json:"length"`
+	Counter   int    `json:"counter"`
+}
+
+// parseLessPass reads a LessPass JSON export (an array of site entries) and
+// converts each entry's character-class flags into a kagi charset class.
+func parseLessPass(data []byte) ([]importedSite, error) {
+	var entries []lessPassEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	sites := make([]importedSite, 0, len(entries))
+	for _, e := range entries {
+		sites = append(sites, importedSite{
+			Name:     e.Site,
+			Username: e.Login,
+			Length:   e.Length,
+			Charset:  lessPassCharset(e),
+			Counter:  e.Counter,
+		})
+	}
+	return sites, nil
+}
+
+func lessPassCharset(e lessPassEntry) string {
+	switch {
+	case e.Symbols:
+		return "alphanumeric+symbols"
+	case e.Lowercase && e.Uppercase && e.Numbers:
+		return "alphanumeric"
+	case e.Numbers && !e.Lowercase && !e.Uppercase:
+		return "numeric"
+	case e.Lowercase && !e.Uppercase:
+		return "lower"
+	case e.Uppercase && !e.Lowercase:
+		return "upper"
+	default:
+		return "alphanumeric"
+	}
+}
@@ -15,7 +15,6 @@
 package main
 
 import (
-	"crypto/sha512"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -84,12 +83,12 @@ func ParseFilter(line string) Filter {
 
 func filterDigits(str string) string {
 	for i := 0; i < 20; i++ {
-		str = strings.ReplaceAll(str, string('a'+i), string('0'+i%10))
-		str = strings.ReplaceAll(str, string('A'+i), string('0'+i%10))
+		str = strings.ReplaceAll(str, string(rune('a'+i)), string(rune('0'+i%10)))
+		str = strings.ReplaceAll(str, string(rune('A'+i)), string(rune('0'+i%10)))
 	}
 	for i := 20; i < 26; i++ {
-		str = strings.ReplaceAll(str, string('a'+i), "")
-		str = strings.ReplaceAll(str, string('A'+i), "")
+		str = strings.ReplaceAll(str, string(rune('a'+i)), "")
+		str = strings.ReplaceAll(str, string(rune('A'+i)), "")
 	}
 	str = strings.ReplaceAll(str, "+", "")
 	str = strings.ReplaceAll(str, "/", "")
@@ -118,54 +117,91 @@ func filterSubstring(str string, start, end int) string {
 type Site struct {
 	Name    string
 	Filters []Filter
-}
-
-func showUsage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s SITES_FILE MASTER_PASS_FILE\n", os.Args[0])
+	KDF     KDF
+	Counter int
 }
 
 func (s *Site) Password(masterPass string) string {
-	str := fmt.Sprintf("%s:%s", s.Name, masterPass)
-	bytePass := sha512.Sum512([]byte(str))
-	pass := base64.StdEncoding.EncodeToString(bytePass[:])[0:32]
+	kdf := s.KDF
+	if kdf == nil {
+		kdf = defaultKDF
+	}
+	name := s.Name
+	if s.Counter != 0 {
+		name = fmt.Sprintf("%s:%d", s.Name, s.Counter)
+	}
+	derived := kdf.Derive(name, masterPass)
+	pass := base64.StdEncoding.EncodeToString(derived)[0:32]
 	for _, filter := range s.Filters {
 		pass = filter(pass)
 	}
 	return pass
 }
 
-func loadSites(filename string) []*Site {
+func loadSites(filename string) ([]*Site, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer file.Close()
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	lines := strings.Split(string(fileContent), "\n")
 	sites := []*Site{}
 	latestFilters := []Filter{}
+	var latestKDF KDF
+	latestCounter := 0
 	for _, line := range lines {
 		line := strings.TrimSpace(line)
 		switch {
 		case line == "":
 			latestFilters = []Filter{}
+			latestKDF = nil
+			latestCounter = 0
 		case line[0] == '#':
+			kdf, err := ParseKDF(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+			if kdf != nil {
+				latestKDF = kdf
+				continue
+			}
+			if counter, ok := ParseCounter(line); ok {
+				latestCounter = counter
+				continue
+			}
 			filter := ParseFilter(line)
 			if filter != nil {
 				latestFilters = append(latestFilters, filter)
 			}
 		default:
+			name, counter := parseSiteLine(line, latestCounter)
 			site := &Site{
-				Name:    line,
+				Name:    name,
 				Filters: latestFilters,
+				KDF:     latestKDF,
+				Counter: counter,
 			}
 			sites = append(sites, site)
 		}
 	}
-	return sites
+	return sites, nil
+}
+
+// parseSiteLine splits a site line into its name and counter. A site line is
+// normally just the site name, but it may carry a trailing `@counter N`
+// override that applies to that site only, e.g. "example.com  @counter 3".
+func parseSiteLine(line string, defaultCounter int) (string, int) {
+	fields := strings.Fields(line)
+	if len(fields) >= 3 && fields[1] == "@counter" {
+		if counter, err := strconv.Atoi(fields[2]); err == nil {
+			return fields[0], counter
+		}
+	}
+	return line, defaultCounter
 }
 
 func isAccessibleOnlyByOwner(filename string) bool {
@@ -195,31 +231,3 @@ func loadMasterPassword(filename string) string {
 	}
 	return strings.TrimSpace(string(fileContent))
 }
-
-var sites []*Site
-var masterPassword string
-
-func init() {
-	if len(os.Args) != 3 {
-		showUsage()
-		os.Exit(-1)
-	}
-	sites = loadSites(os.Args[1])
-	masterPassword = loadMasterPassword(os.Args[2])
-}
-
-func main() {
-	longestSiteLen := 0
-	for _, site := range sites {
-		siteLen := len(site.Name)
-		if longestSiteLen < siteLen {
-			longestSiteLen = siteLen
-		}
-	}
-	for _, site := range sites {
-		spaceNum := longestSiteLen - len(site.Name) + 1
-		spaceStr := strings.Repeat(" ", spaceNum)
-		fmt.Printf("%s:%s%s\n", site.Name, spaceStr,
-			site.Password(masterPassword))
-	}
-}
@@ -0,0 +1,80 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// openTTY opens the controlling terminal for interactive prompts, independent
+// of whatever stdin has been redirected to.
+func openTTY() *os.File {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		panic(err)
+	}
+	return tty
+}
+
+func readPassword(tty *os.File, prompt string) []byte {
+	fmt.Fprint(tty, prompt)
+	pass, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		panic(err)
+	}
+	return pass
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// promptMasterPassword reads the master password from the controlling TTY
+// with echo disabled. If confirm is true, it re-prompts and exits unless
+// both entries match. The byte buffers backing the input are zeroed before
+// this function returns.
+func promptMasterPassword(confirm bool) string {
+	tty := openTTY()
+	defer tty.Close()
+
+	pass := readPassword(tty, "Master password: ")
+	defer zero(pass)
+
+	if confirm {
+		confirmPass := readPassword(tty, "Confirm master password: ")
+		defer zero(confirmPass)
+		if string(pass) != string(confirmPass) {
+			fmt.Fprintln(os.Stderr, "kagi: master passwords do not match")
+			os.Exit(1)
+		}
+	}
+	return string(pass)
+}
+
+// printConfirmHash prints a short, truncated hash of the master password so
+// the user can visually verify they typed the same master they used last
+// time, before any site passwords are derived from it.
+func printConfirmHash(masterPassword string) {
+	sum := sha256.Sum256([]byte(masterPassword))
+	fmt.Fprintf(os.Stderr, "Master password hash: %s\n", hex.EncodeToString(sum[:])[:4])
+}
@@ -0,0 +1,101 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mpsitesTypeRe matches the "type:algorithm:counter" field of an .mpsites
+// record, e.g. "0x10:3:1".
+var mpsitesTypeRe = regexp.MustCompile(`^(?:0x)?([0-9a-fA-F]+):(\d+):(\d+)$`)
+
+// mpsitesTemplates maps Master Password's standard generated-password
+// template type codes to the length and character class they produce.
+// Unrecognized codes fall back to mpsitesDefaultCharset/-Length.
+var mpsitesTemplates = map[int64]struct {
+	length  int
+	charset string
+}{
+	0x10: {20, "alphanumeric+symbols"}, // Maximum Security
+	0x11: {14, "alphanumeric"},         // Long
+	0x12: {8, "alphanumeric"},          // Medium
+	0x13: {8, "alphanumeric"},          // Basic
+	0x14: {4, "alphanumeric"},          // Short
+	0x15: {4, "numeric"},               // PIN
+}
+
+const (
+	mpsitesDefaultLength  = 16
+	mpsitesDefaultCharset = "alphanumeric+symbols"
+)
+
+// parseMPSites reads a Master Password (Maelstrom) .mpsites export. The
+// format is a handful of `#`-prefixed header lines followed by one
+// tab-separated record per site: last-used, times-used, "type:algo:counter",
+// login name, and site name.
+func parseMPSites(data []byte) ([]importedSite, error) {
+	var sites []importedSite
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			// Older exports pad columns with runs of spaces instead of tabs.
+			fields = regexp.MustCompile(` {2,}`).Split(strings.TrimSpace(line), -1)
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed record: %q", line)
+		}
+
+		name := strings.TrimSpace(fields[len(fields)-1])
+		if name == "" {
+			continue
+		}
+		var login string
+		if len(fields) >= 2 {
+			login = strings.TrimSpace(fields[len(fields)-2])
+		}
+
+		length, charset, counter := mpsitesDefaultLength, mpsitesDefaultCharset, 0
+		for _, field := range fields {
+			m := mpsitesTypeRe.FindStringSubmatch(strings.TrimSpace(field))
+			if m == nil {
+				continue
+			}
+			typeCode, _ := strconv.ParseInt(m[1], 16, 64)
+			counter, _ = strconv.Atoi(m[3])
+			if tmpl, ok := mpsitesTemplates[typeCode]; ok {
+				length, charset = tmpl.length, tmpl.charset
+			}
+			break
+		}
+
+		sites = append(sites, importedSite{
+			Name:     name,
+			Username: login,
+			Length:   length,
+			Charset:  charset,
+			Counter:  counter,
+		})
+	}
+	return sites, nil
+}